@@ -1,16 +1,31 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"flag"
+	"log/slog"
+	"os"
 
 	"ai-blockchain/pkg/config"
 )
 
 func main() {
-	cfg, err := config.LoadConfig("config.json")
+	configFlag := flag.String("config", "", "path to config.json (searched for if empty)")
+	portFlag := flag.String("port", "", "port to listen on (overrides config)")
+	flag.Parse()
+
+	cfg, path, err := config.FindAndLoadConfig(*configFlag)
+	if err != nil {
+		slog.Error("Failed to load config", "path", path, "err", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(config.SetupLogger(cfg))
+
+	slog.Info("Loaded config", "path", path, "config", cfg)
+
+	port, err := cfg.ResolvePort(*portFlag)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		slog.Error("Invalid port", "err", err)
+		os.Exit(1)
 	}
-	fmt.Printf("Loaded config: %+v\n", cfg)
+	slog.Info("Listening port resolved", "port", port)
 }
@@ -1,21 +1,45 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const (
+	DefaultNetworkPort            = 6000
+	DefaultMiningDifficultyTarget = "0000"
+	DefaultMaxBlockTransactions   = 10
+	DefaultVMExecutionTimeout     = 30
+	DefaultLogLevel               = "info"
+	DefaultLogFormat              = "json"
+	DefaultWatchInterval          = time.Second
+)
+
+// Config holds the node settings read from config.json. Any field can be
+// overridden by the AIBC_* environment variable noted beside it; a variable
+// that is set but empty is treated as unset.
 type Config struct {
-	NetworkPort            int    `json:"networkPort"`
-	MiningDifficultyTarget string `json:"miningDifficultyTarget"`
-	IPFSGatewayURL         string `json:"ipfsGatewayURL"`
-	DataDir                string `json:"dataDir"`
-	MaxBlockTransactions   int    `json:"maxBlockTransactions"`
-	VMExecutionTimeout     int    `json:"vmExecutionTimeout"`
+	NetworkPort            int    `json:"networkPort"`            // AIBC_NETWORK_PORT
+	MiningDifficultyTarget string `json:"miningDifficultyTarget"` // AIBC_MINING_DIFFICULTY
+	IPFSGatewayURL         string `json:"ipfsGatewayURL"`         // AIBC_IPFS_URL
+	DataDir                string `json:"dataDir"`                // AIBC_DATA_DIR
+	MaxBlockTransactions   int    `json:"maxBlockTransactions"`   // AIBC_MAX_BLOCK_TRANSACTIONS
+	VMExecutionTimeout     int    `json:"vmExecutionTimeout"`     // AIBC_VM_EXECUTION_TIMEOUT
+	LogLevel               string `json:"logLevel"`               // AIBC_LOG_LEVEL
+	LogFormat              string `json:"logFormat"`              // AIBC_LOG_FORMAT
 }
 
-func LoadConfig(filepath string) (*Config, error) {
-	file, err := os.Open(filepath)
+func LoadConfig(path string) (*Config, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
@@ -26,5 +50,211 @@ func LoadConfig(filepath string) (*Config, error) {
 	if err := decoder.Decode(config); err != nil {
 		return nil, err
 	}
+	if err := config.applyEnv(); err != nil {
+		return nil, err
+	}
+	config.applyDefaults()
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
+
+func (c *Config) applyEnv() error {
+	stringVars := []struct {
+		name  string
+		field *string
+	}{
+		{"AIBC_MINING_DIFFICULTY", &c.MiningDifficultyTarget},
+		{"AIBC_IPFS_URL", &c.IPFSGatewayURL},
+		{"AIBC_DATA_DIR", &c.DataDir},
+		{"AIBC_LOG_LEVEL", &c.LogLevel},
+		{"AIBC_LOG_FORMAT", &c.LogFormat},
+	}
+	for _, v := range stringVars {
+		if val := os.Getenv(v.name); val != "" {
+			*v.field = val
+		}
+	}
+
+	intVars := []struct {
+		name  string
+		field *int
+	}{
+		{"AIBC_NETWORK_PORT", &c.NetworkPort},
+		{"AIBC_MAX_BLOCK_TRANSACTIONS", &c.MaxBlockTransactions},
+		{"AIBC_VM_EXECUTION_TIMEOUT", &c.VMExecutionTimeout},
+	}
+	for _, v := range intVars {
+		val := os.Getenv(v.name)
+		if val == "" {
+			continue
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("config: invalid %s %q: %w", v.name, val, err)
+		}
+		*v.field = n
+	}
+	return nil
+}
+
+// applyDefaults fills fields that were left unset. Zero values, whether
+// omitted or written explicitly, are treated as unset; any other value is
+// kept as is so that Validate can report it if invalid.
+func (c *Config) applyDefaults() {
+	if c.NetworkPort == 0 {
+		c.NetworkPort = DefaultNetworkPort
+	}
+	if c.MiningDifficultyTarget == "" {
+		c.MiningDifficultyTarget = DefaultMiningDifficultyTarget
+	}
+	if c.MaxBlockTransactions == 0 {
+		c.MaxBlockTransactions = DefaultMaxBlockTransactions
+	}
+	if c.VMExecutionTimeout == 0 {
+		c.VMExecutionTimeout = DefaultVMExecutionTimeout
+	}
+	if c.LogLevel == "" {
+		c.LogLevel = DefaultLogLevel
+	}
+	if c.LogFormat == "" {
+		c.LogFormat = DefaultLogFormat
+	}
+}
+
+// Validate reports the first required field that is missing or malformed.
+func (c *Config) Validate() error {
+	if c.IPFSGatewayURL == "" {
+		return errors.New("config: ipfsGatewayURL is required")
+	}
+	if c.MiningDifficultyTarget == "" {
+		return errors.New("config: miningDifficultyTarget is required")
+	}
+	if c.NetworkPort < 1 || c.NetworkPort > 65535 {
+		return fmt.Errorf("config: networkPort %d must be between 1 and 65535", c.NetworkPort)
+	}
+	if c.MaxBlockTransactions < 1 {
+		return fmt.Errorf("config: maxBlockTransactions %d must be positive", c.MaxBlockTransactions)
+	}
+	if c.VMExecutionTimeout < 1 {
+		return fmt.Errorf("config: vmExecutionTimeout %d must be positive", c.VMExecutionTimeout)
+	}
+	// Only the number of leading zero hex digits is used as the PoW target,
+	// so anything other than a run of zeros is almost certainly a mistake.
+	if strings.Trim(c.MiningDifficultyTarget, "0") != "" {
+		return fmt.Errorf("config: miningDifficultyTarget %q must contain only '0' characters", c.MiningDifficultyTarget)
+	}
+	return nil
+}
+
+// ResolvePort returns the port the node should listen on. A non-empty flag
+// value wins and must be a port between 1 and 65535; otherwise NetworkPort is
+// used, which LoadConfig has already overridden from AIBC_NETWORK_PORT when
+// that is set.
+func (c *Config) ResolvePort(flagVal string) (string, error) {
+	if flagVal == "" {
+		return strconv.Itoa(c.NetworkPort), nil
+	}
+	port, err := strconv.Atoi(flagVal)
+	if err != nil || port < 1 || port > 65535 {
+		return "", fmt.Errorf("config: port flag %q must be between 1 and 65535", flagVal)
+	}
+	return strconv.Itoa(port), nil
+}
+
+// WatchConfig polls path every interval and calls onChange with the reloaded
+// config each time the file changes, until ctx is cancelled. A non-positive
+// interval is replaced by DefaultWatchInterval. A file that fails to load or
+// validate (for example a partial write or a typo) is not applied; it is
+// logged once per distinct version and retried on the next poll.
+//
+// Changes are detected by modification time and size only, so a rewrite
+// that keeps the same size within the filesystem's mtime granularity can be
+// missed.
+func WatchConfig(ctx context.Context, path string, interval time.Duration, onChange func(*Config)) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	type fileState struct {
+		mod  time.Time
+		size int64
+	}
+	var applied, rejected fileState
+	if info, err := os.Stat(path); err == nil {
+		applied = fileState{info.ModTime(), info.Size()}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		current := fileState{info.ModTime(), info.Size()}
+		if current.mod.Equal(applied.mod) && current.size == applied.size {
+			continue
+		}
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			if !current.mod.Equal(rejected.mod) || current.size != rejected.size {
+				slog.Warn("config reload rejected", "path", path, "err", err)
+				rejected = current
+			}
+			continue
+		}
+		applied = current
+		onChange(cfg)
+	}
+}
+
+// searchPaths lists where FindAndLoadConfig looks for a config file when no
+// path was given explicitly: ./config.json, then $HOME/.aibc/config.json.
+func searchPaths() []string {
+	paths := []string{"config.json"}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".aibc", "config.json"))
+	}
+	return paths
+}
+
+// FindAndLoadConfig loads the node config and returns it along with the path
+// that was used. A non-empty explicit path, or else $AIBC_CONFIG, is used as
+// is and must exist. Otherwise the first existing file in ./config.json and
+// $HOME/.aibc/config.json is loaded.
+func FindAndLoadConfig(explicit string) (*Config, string, error) {
+	if explicit == "" {
+		explicit = os.Getenv("AIBC_CONFIG")
+	}
+	if explicit != "" {
+		cfg, err := LoadConfig(explicit)
+		if err != nil {
+			return nil, explicit, err
+		}
+		return cfg, explicit, nil
+	}
+
+	paths := searchPaths()
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, path, err
+		}
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			return nil, path, err
+		}
+		return cfg, path, nil
+	}
+	return nil, "", fmt.Errorf("config: no config file found in %s", strings.Join(paths, ", "))
+}
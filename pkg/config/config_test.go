@@ -0,0 +1,485 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets every AIBC_* override for the duration of the test so
+// results don't depend on the caller's environment.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"AIBC_CONFIG",
+		"AIBC_NETWORK_PORT",
+		"AIBC_MINING_DIFFICULTY",
+		"AIBC_IPFS_URL",
+		"AIBC_DATA_DIR",
+		"AIBC_MAX_BLOCK_TRANSACTIONS",
+		"AIBC_VM_EXECUTION_TIMEOUT",
+		"AIBC_LOG_LEVEL",
+		"AIBC_LOG_FORMAT",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func writeConfig(t *testing.T, dir, body string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("failed to create %s: %v", dir, err)
+	}
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestLoadConfigAppliesDefaults(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, t.TempDir(), `{"ipfsGatewayURL": "http://localhost:5001"}`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.NetworkPort != DefaultNetworkPort {
+		t.Errorf("NetworkPort = %d, want %d", cfg.NetworkPort, DefaultNetworkPort)
+	}
+	if cfg.MiningDifficultyTarget != DefaultMiningDifficultyTarget {
+		t.Errorf("MiningDifficultyTarget = %q, want %q", cfg.MiningDifficultyTarget, DefaultMiningDifficultyTarget)
+	}
+	if cfg.MaxBlockTransactions != DefaultMaxBlockTransactions {
+		t.Errorf("MaxBlockTransactions = %d, want %d", cfg.MaxBlockTransactions, DefaultMaxBlockTransactions)
+	}
+	if cfg.VMExecutionTimeout != DefaultVMExecutionTimeout {
+		t.Errorf("VMExecutionTimeout = %d, want %d", cfg.VMExecutionTimeout, DefaultVMExecutionTimeout)
+	}
+}
+
+func TestLoadConfigRejectsInvalidValues(t *testing.T) {
+	clearEnv(t)
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"missing IPFS URL", `{}`, "ipfsGatewayURL is required"},
+		{"negative port", `{"ipfsGatewayURL": "x", "networkPort": -1}`, "networkPort -1"},
+		{"port out of range", `{"ipfsGatewayURL": "x", "networkPort": 99999}`, "networkPort 99999"},
+		{"negative max transactions", `{"ipfsGatewayURL": "x", "maxBlockTransactions": -5}`, "maxBlockTransactions -5"},
+		{"negative VM timeout", `{"ipfsGatewayURL": "x", "vmExecutionTimeout": -1}`, "vmExecutionTimeout -1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, t.TempDir(), tt.body)
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadConfig error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigEnvOverridesFile(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, t.TempDir(), `{
+		"networkPort": 6000,
+		"ipfsGatewayURL": "http://file:5001",
+		"maxBlockTransactions": 5
+	}`)
+	t.Setenv("AIBC_NETWORK_PORT", "7001")
+	t.Setenv("AIBC_IPFS_URL", "http://env:5001")
+	t.Setenv("AIBC_MAX_BLOCK_TRANSACTIONS", "20")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.NetworkPort != 7001 {
+		t.Errorf("NetworkPort = %d, want 7001", cfg.NetworkPort)
+	}
+	if cfg.IPFSGatewayURL != "http://env:5001" {
+		t.Errorf("IPFSGatewayURL = %q, want %q", cfg.IPFSGatewayURL, "http://env:5001")
+	}
+	if cfg.MaxBlockTransactions != 20 {
+		t.Errorf("MaxBlockTransactions = %d, want 20", cfg.MaxBlockTransactions)
+	}
+}
+
+func TestLoadConfigIgnoresEmptyEnv(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, t.TempDir(), `{"networkPort": 6001, "ipfsGatewayURL": "http://file:5001"}`)
+	t.Setenv("AIBC_NETWORK_PORT", "")
+	t.Setenv("AIBC_IPFS_URL", "")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.NetworkPort != 6001 {
+		t.Errorf("NetworkPort = %d, want 6001", cfg.NetworkPort)
+	}
+	if cfg.IPFSGatewayURL != "http://file:5001" {
+		t.Errorf("IPFSGatewayURL = %q, want %q", cfg.IPFSGatewayURL, "http://file:5001")
+	}
+}
+
+func TestLoadConfigRejectsInvalidIntEnv(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, t.TempDir(), `{"ipfsGatewayURL": "http://file:5001"}`)
+	t.Setenv("AIBC_NETWORK_PORT", "not-a-port")
+
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "AIBC_NETWORK_PORT") {
+		t.Fatalf("LoadConfig error = %v, want one naming AIBC_NETWORK_PORT", err)
+	}
+}
+
+func TestResolvePortPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, t.TempDir(), `{"networkPort": 6001, "ipfsGatewayURL": "http://file:5001"}`)
+
+	resolve := func(flagVal string) string {
+		t.Helper()
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		port, err := cfg.ResolvePort(flagVal)
+		if err != nil {
+			t.Fatalf("ResolvePort(%q) failed: %v", flagVal, err)
+		}
+		return port
+	}
+
+	if got := resolve(""); got != "6001" {
+		t.Errorf("file only: ResolvePort = %q, want %q", got, "6001")
+	}
+
+	t.Setenv("AIBC_NETWORK_PORT", "7001")
+	if got := resolve(""); got != "7001" {
+		t.Errorf("env over file: ResolvePort = %q, want %q", got, "7001")
+	}
+	if got := resolve("8001"); got != "8001" {
+		t.Errorf("flag over env: ResolvePort = %q, want %q", got, "8001")
+	}
+}
+
+func TestResolvePortRejectsInvalidFlag(t *testing.T) {
+	cfg := &Config{NetworkPort: DefaultNetworkPort}
+	for _, flagVal := range []string{"abc", "0", "-1", "70000"} {
+		if _, err := cfg.ResolvePort(flagVal); err == nil {
+			t.Errorf("ResolvePort(%q) succeeded, want an error", flagVal)
+		}
+	}
+}
+
+func TestWatchConfigReloadsOnChange(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, t.TempDir(), `{"ipfsGatewayURL": "http://file:5001", "maxBlockTransactions": 5}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan *Config, 1)
+	done := make(chan struct{})
+	go func() {
+		WatchConfig(ctx, path, 10*time.Millisecond, func(cfg *Config) {
+			select {
+			case changes <- cfg:
+			default:
+			}
+		})
+		close(done)
+	}()
+
+	// The watcher records the file's initial state when it starts, which may
+	// be after our first rewrite. Keep rewriting with a fresh mtime until a
+	// change is seen so the test doesn't depend on goroutine scheduling.
+	body := []byte(`{"ipfsGatewayURL": "http://file:5001", "maxBlockTransactions": 42, "miningDifficultyTarget": "000000"}`)
+	deadline := time.After(2 * time.Second)
+	var cfg *Config
+	for i := 1; cfg == nil; i++ {
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			t.Fatalf("failed to rewrite config: %v", err)
+		}
+		mtime := time.Now().Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("failed to update mtime: %v", err)
+		}
+		select {
+		case cfg = <-changes:
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("onChange was not called after the config file changed")
+		}
+	}
+
+	if cfg.MaxBlockTransactions != 42 {
+		t.Errorf("MaxBlockTransactions = %d, want 42", cfg.MaxBlockTransactions)
+	}
+	if cfg.MiningDifficultyTarget != "000000" {
+		t.Errorf("MiningDifficultyTarget = %q, want %q", cfg.MiningDifficultyTarget, "000000")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("WatchConfig did not return after the context was cancelled")
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe for a logger goroutine to write while
+// the test reads it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) count(substr string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Count(b.buf.String(), substr)
+}
+
+func TestWatchConfigLogsRejectedReloadOnce(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, t.TempDir(), `{"ipfsGatewayURL": "http://file:5001"}`)
+
+	var logs lockedBuffer
+	orig := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(orig) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		WatchConfig(ctx, path, 10*time.Millisecond, func(*Config) {
+			t.Error("onChange called for an invalid config")
+		})
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Rewrite with a fresh mtime until the watcher notices, as in
+	// TestWatchConfigReloadsOnChange.
+	deadline := time.Now().Add(2 * time.Second)
+	for i := 1; logs.count("config reload rejected") == 0; i++ {
+		if time.Now().After(deadline) {
+			t.Fatal("invalid config reload was not logged")
+		}
+		if err := os.WriteFile(path, []byte(`{}`), 0o644); err != nil {
+			t.Fatalf("failed to rewrite config: %v", err)
+		}
+		mtime := time.Now().Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("failed to update mtime: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// With the file left alone, later polls must not log it again.
+	before := logs.count("config reload rejected")
+	time.Sleep(100 * time.Millisecond)
+	if after := logs.count("config reload rejected"); after != before {
+		t.Errorf("rejection logged %d more times for an unchanged file", after-before)
+	}
+}
+
+func TestWatchConfigNonPositiveInterval(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, t.TempDir(), `{"ipfsGatewayURL": "http://file:5001"}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Must not panic; with ctx already cancelled it returns immediately.
+	WatchConfig(ctx, path, 0, func(*Config) {})
+	WatchConfig(ctx, path, -time.Second, func(*Config) {})
+}
+
+func TestValidateMiningDifficultyTarget(t *testing.T) {
+	tests := []struct {
+		target  string
+		wantErr bool
+	}{
+		{"", true},
+		{"0000", false},
+		{"0", false},
+		{"xyz", true},
+		{"00a0", true},
+		{"ab", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			cfg := &Config{
+				NetworkPort:            DefaultNetworkPort,
+				MiningDifficultyTarget: tt.target,
+				IPFSGatewayURL:         "http://localhost:5001",
+				MaxBlockTransactions:   DefaultMaxBlockTransactions,
+				VMExecutionTimeout:     DefaultVMExecutionTimeout,
+			}
+			err := cfg.Validate()
+			if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "miningDifficultyTarget")) {
+				t.Fatalf("Validate(%q) error = %v, want a miningDifficultyTarget error", tt.target, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("Validate(%q) unexpected error: %v", tt.target, err)
+			}
+		})
+	}
+}
+
+// chdir switches the working directory to dir for the rest of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	orig, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change to %s: %v", dir, err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(orig); err != nil {
+			t.Fatalf("failed to restore working directory: %v", err)
+		}
+	})
+}
+
+// setupSearchDirs points the working directory and $HOME at fresh temp dirs
+// and clears the AIBC_* overrides, returning the working and home
+// directories.
+func setupSearchDirs(t *testing.T) (string, string) {
+	t.Helper()
+	cwd, home := t.TempDir(), t.TempDir()
+	chdir(t, cwd)
+	t.Setenv("HOME", home)
+	clearEnv(t)
+	return cwd, home
+}
+
+func portConfig(port int) string {
+	return `{"ipfsGatewayURL": "http://localhost:5001", "networkPort": ` + strconv.Itoa(port) + `}`
+}
+
+func TestFindAndLoadConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		explicit bool
+		env      bool
+		cwd      bool
+		home     bool
+		wantPort int
+	}{
+		{"explicit beats all", true, true, true, true, 7001},
+		{"env beats cwd and home", false, true, true, true, 7002},
+		{"cwd beats home", false, false, true, true, 7003},
+		{"home only", false, false, false, true, 7004},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cwd, home := setupSearchDirs(t)
+
+			var explicit string
+			if tt.explicit {
+				explicit = writeConfig(t, filepath.Join(t.TempDir(), "explicit"), portConfig(7001))
+			}
+			if tt.env {
+				t.Setenv("AIBC_CONFIG", writeConfig(t, filepath.Join(t.TempDir(), "env"), portConfig(7002)))
+			}
+			if tt.cwd {
+				writeConfig(t, cwd, portConfig(7003))
+			}
+			if tt.home {
+				writeConfig(t, filepath.Join(home, ".aibc"), portConfig(7004))
+			}
+
+			cfg, path, err := FindAndLoadConfig(explicit)
+			if err != nil {
+				t.Fatalf("FindAndLoadConfig failed: %v", err)
+			}
+			if cfg.NetworkPort != tt.wantPort {
+				t.Errorf("loaded %s with NetworkPort %d, want %d", path, cfg.NetworkPort, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestFindAndLoadConfigMissingExplicitPath(t *testing.T) {
+	cwd, _ := setupSearchDirs(t)
+	writeConfig(t, cwd, portConfig(7003))
+
+	missing := filepath.Join(t.TempDir(), "typo.json")
+	if _, _, err := FindAndLoadConfig(missing); err == nil {
+		t.Fatal("expected an error for a missing explicit config path")
+	}
+
+	t.Setenv("AIBC_CONFIG", missing)
+	if _, _, err := FindAndLoadConfig(""); err == nil {
+		t.Fatal("expected an error for a missing $AIBC_CONFIG path")
+	}
+}
+
+func TestFindAndLoadConfigNoneFound(t *testing.T) {
+	setupSearchDirs(t)
+
+	if _, _, err := FindAndLoadConfig(""); err == nil || !strings.Contains(err.Error(), "no config file found") {
+		t.Fatalf("FindAndLoadConfig error = %v, want a not-found error", err)
+	}
+}
+
+func TestValidateRequiresFieldsWithoutDefaults(t *testing.T) {
+	base := Config{
+		NetworkPort:            DefaultNetworkPort,
+		MiningDifficultyTarget: DefaultMiningDifficultyTarget,
+		IPFSGatewayURL:         "http://localhost:5001",
+		MaxBlockTransactions:   DefaultMaxBlockTransactions,
+		VMExecutionTimeout:     DefaultVMExecutionTimeout,
+	}
+	if err := base.Validate(); err != nil {
+		t.Fatalf("Validate failed on a complete config: %v", err)
+	}
+
+	noURL := base
+	noURL.IPFSGatewayURL = ""
+	if err := noURL.Validate(); err == nil || !strings.Contains(err.Error(), "ipfsGatewayURL is required") {
+		t.Errorf("Validate error = %v, want ipfsGatewayURL is required", err)
+	}
+
+	noDifficulty := base
+	noDifficulty.MiningDifficultyTarget = ""
+	if err := noDifficulty.Validate(); err == nil || !strings.Contains(err.Error(), "miningDifficultyTarget is required") {
+		t.Errorf("Validate error = %v, want miningDifficultyTarget is required", err)
+	}
+}
+
+func TestLoadConfigReportsFirstInvalidIntEnv(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, t.TempDir(), `{"ipfsGatewayURL": "http://file:5001"}`)
+	t.Setenv("AIBC_NETWORK_PORT", "bad")
+	t.Setenv("AIBC_MAX_BLOCK_TRANSACTIONS", "bad")
+	t.Setenv("AIBC_VM_EXECUTION_TIMEOUT", "bad")
+
+	for i := 0; i < 10; i++ {
+		if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "AIBC_NETWORK_PORT") {
+			t.Fatalf("LoadConfig error = %v, want one naming AIBC_NETWORK_PORT", err)
+		}
+	}
+}
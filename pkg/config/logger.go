@@ -0,0 +1,45 @@
+package config
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// SetupLogger builds the node logger from LogLevel ("debug", "info", "warn",
+// "error") and LogFormat ("json" or "text"), writing to stderr. Unknown
+// values fall back to info/json and a warning is logged.
+func SetupLogger(cfg *Config) *slog.Logger {
+	return newLogger(cfg, os.Stderr)
+}
+
+func newLogger(cfg *Config, w io.Writer) *slog.Logger {
+	var level slog.Level
+	badLevel := level.UnmarshalText([]byte(cfg.LogLevel)) != nil
+	if badLevel {
+		level = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	badFormat := false
+	switch strings.ToLower(cfg.LogFormat) {
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		handler = slog.NewJSONHandler(w, opts)
+		badFormat = true
+	}
+
+	logger := slog.New(handler)
+	if badLevel {
+		logger.Warn("invalid logLevel, using default", "value", cfg.LogLevel, "default", DefaultLogLevel)
+	}
+	if badFormat {
+		logger.Warn("invalid logFormat, using default", "value", cfg.LogFormat, "default", DefaultLogFormat)
+	}
+	return logger
+}
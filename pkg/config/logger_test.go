@@ -0,0 +1,63 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLoggerWarnLevelSuppressesDebug(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger(&Config{LogLevel: "warn", LogFormat: "json"}, &buf)
+
+	logger.Debug("debug line")
+	logger.Info("info line")
+	logger.Warn("warn line")
+
+	out := buf.String()
+	if strings.Contains(out, "debug line") || strings.Contains(out, "info line") {
+		t.Errorf("expected debug and info lines to be suppressed, got %q", out)
+	}
+	if !strings.Contains(out, "warn line") {
+		t.Errorf("expected warn line in output, got %q", out)
+	}
+}
+
+func TestLoggerDebugLevelEmitsDebug(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger(&Config{LogLevel: "debug", LogFormat: "text"}, &buf)
+
+	logger.Debug("debug line")
+
+	if !strings.Contains(buf.String(), "debug line") {
+		t.Errorf("expected debug line in output, got %q", buf.String())
+	}
+}
+
+func TestLoggerInvalidLevelFallsBack(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger(&Config{LogLevel: "loud", LogFormat: "json"}, &buf)
+
+	out := buf.String()
+	if !strings.Contains(out, `"msg":"invalid logLevel, using default"`) ||
+		!strings.Contains(out, `"value":"loud"`) || !strings.Contains(out, `"default":"info"`) {
+		t.Errorf("expected structured fallback warning, got %q", out)
+	}
+
+	buf.Reset()
+	logger.Debug("debug line")
+	logger.Info("info line")
+	if strings.Contains(buf.String(), "debug line") || !strings.Contains(buf.String(), "info line") {
+		t.Errorf("expected fallback to info level, got %q", buf.String())
+	}
+}
+
+func TestLoggerInvalidFormatFallsBack(t *testing.T) {
+	var buf bytes.Buffer
+	newLogger(&Config{LogLevel: "info", LogFormat: "xml"}, &buf)
+
+	out := buf.String()
+	if !strings.Contains(out, `"msg":"invalid logFormat, using default"`) || !strings.Contains(out, `"value":"xml"`) {
+		t.Errorf("expected structured JSON fallback warning, got %q", out)
+	}
+}